@@ -1,34 +1,127 @@
 package pagination
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // Paginator is a general purpose pagination type, it knows how to calculate
 // offset and number of pages. It also contains some utility functions
 // that helps common tasks. One special utility is the PagesStream method
-// that returns a channel to range over for presenting a list of all pages
+// that returns an Iterator to walk over for presenting a list of all pages
 // without adding them all to a slice.
+//
+// Besides the default offset/page-number mode, a Paginator can also be put
+// into cursor (keyset) mode via NewFromCursor, which is better suited for
+// large collections since it avoids the missed/duplicated rows offset
+// pagination is prone to under concurrent inserts.
 type Paginator struct {
 	itemsPerPage  int
 	numberOfItems int
 	currentPage   int
+
+	cursorMode     bool
+	cursor         *Cursor
+	nextCursor     *Cursor
+	previousCursor *Cursor
+	hasMore        bool
+
+	groupMode       bool
+	groups          Groups
+	groupTotalItems int
+
+	reversed bool
+
+	maxPages int
+
+	urlFactory URLFactory
 }
 
+// URLFactory builds the URL for a given page number. A Paginator with a
+// URLFactory attached can emit Link and Content-Range headers via
+// WriteHeaders.
+type URLFactory func(page int) string
+
 // Pagination is a public version of the paginator. It does not have any logic
 // attached and can be easily serialized to JSON.
 type Pagination struct {
-	ItemsPerPage  int           `json:"per_page"`
-	NumberOfItems int           `json:"total_entries"`
-	CurrentPage   int           `json:"page"`
-	Offset        int           `json:"offset"`
-	NextPage      int           `json:"next_page"`
-	PreviousPage  int           `json:"previous_page"`
-	TotalPages    int           `json:"total_pages"`
-	Data          []interface{} `json:"data"`
+	ItemsPerPage   int           `json:"per_page"`
+	NumberOfItems  int           `json:"total_entries"`
+	CurrentPage    int           `json:"page"`
+	Offset         int           `json:"offset"`
+	NextPage       int           `json:"next_page"`
+	PreviousPage   int           `json:"previous_page"`
+	TotalPages     int           `json:"total_pages"`
+	Data           []interface{} `json:"data"`
+	NextCursor     string        `json:"next_cursor,omitempty"`
+	PreviousCursor string        `json:"prev_cursor,omitempty"`
+	PageGroups     Groups        `json:"page_groups,omitempty"`
+	Reversed       bool          `json:"reversed"`
+}
+
+// Group represents one bucket of pre-grouped items, e.g. all entries that
+// share a date, category, or key. Items holds the group's own entries and
+// is serialized to JSON unchanged.
+type Group struct {
+	Key   string      `json:"key"`
+	Items interface{} `json:"items"`
+}
+
+// Groups is a slice of Group.
+type Groups []Group
+
+// Cursor represents a single position within a keyset-paginated collection.
+// It carries the last seen sort key along with the direction the caller
+// was navigating in. Cursors are transported to and from clients as an
+// opaque, base64-encoded JSON string, normally via the `cursor` query
+// parameter.
+type Cursor struct {
+	Key       string `json:"key"`
+	Direction string `json:"direction"`
+}
+
+// EncodeCursor serializes a Cursor into the opaque string form used on the
+// wire. A nil cursor encodes to an empty string.
+func EncodeCursor(c *Cursor) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses the opaque cursor string produced by EncodeCursor. An
+// empty string decodes to a nil Cursor with no error, which callers should
+// treat as "start from the beginning".
+func DecodeCursor(raw string) (*Cursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.URLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
 }
 
 // New returns a new Pagination with the provided values.
@@ -63,26 +156,271 @@ func NewFromRequest(numberOfItems int, itemsPerPage int, req *http.Request) *Pag
 	return New(numberOfItems, itemsPerPage, currentPage)
 }
 
+// NewFromRequestWithURL is like NewFromRequest but also attaches a
+// URLFactory derived from req, so the resulting Paginator can emit Link
+// and Content-Range headers via WriteHeaders.
+func NewFromRequestWithURL(numberOfItems int, itemsPerPage int, req *http.Request) *Paginator {
+	p := NewFromRequest(numberOfItems, itemsPerPage, req)
+	p.SetURLFactory(URLFactoryFromRequest(req))
+
+	return p
+}
+
+// URLFactoryFromRequest builds a URLFactory that points back at req's URL,
+// preserving every query parameter except page, which is replaced with
+// the requested page number.
+func URLFactoryFromRequest(req *http.Request) URLFactory {
+	base := *req.URL
+	query := base.Query()
+
+	return func(page int) string {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("page", strconv.Itoa(page))
+
+		u := base
+		u.RawQuery = q.Encode()
+
+		return u.String()
+	}
+}
+
+// SetURLFactory attaches a URLFactory to the Paginator, enabling
+// WriteHeaders.
+func (p *Paginator) SetURLFactory(f URLFactory) {
+	p.urlFactory = f
+}
+
+// WriteHeaders emits RFC 5988 Link headers (rel="next", "prev", "first",
+// "last") built from the Paginator's URLFactory, plus a Content-Range
+// header describing the current page's position within the collection. It
+// is a no-op if no URLFactory has been set.
+func (p *Paginator) WriteHeaders(w http.ResponseWriter) {
+	if p.urlFactory == nil {
+		return
+	}
+
+	links := []string{
+		fmt.Sprintf(`<%s>; rel="first"`, p.urlFactory(1)),
+		fmt.Sprintf(`<%s>; rel="last"`, p.urlFactory(p.NumberOfPages())),
+		fmt.Sprintf(`<%s>; rel="next"`, p.urlFactory(p.NextPage())),
+		fmt.Sprintf(`<%s>; rel="prev"`, p.urlFactory(p.PreviousPage())),
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	last := p.Offset() + p.ItemsPerPage()
+	if last > p.NumberOfItems() {
+		last = p.NumberOfItems()
+	}
+
+	w.Header().Set(
+		"Content-Range",
+		fmt.Sprintf("items %d-%d/%d", p.Offset(), last, p.NumberOfItems()),
+	)
+}
+
+// NewFromCursor returns a new Paginator configured for cursor-based
+// (keyset) pagination instead of offset/page-number pagination. cursor is
+// the position the caller resumed from, normally produced by decoding the
+// `cursor` query parameter with DecodeCursor, or nil for the first page.
+func NewFromCursor(itemsPerPage int, cursor *Cursor) *Paginator {
+	return &Paginator{
+		itemsPerPage: itemsPerPage,
+		cursorMode:   true,
+		cursor:       cursor,
+	}
+}
+
+// NewFromCursorRequest is like NewFromCursor but decodes the cursor from
+// the `cursor` query parameter on req.
+func NewFromCursorRequest(itemsPerPage int, req *http.Request) (*Paginator, error) {
+	cursor, err := DecodeCursor(req.URL.Query().Get("cursor"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromCursor(itemsPerPage, cursor), nil
+}
+
+// NewReversed is like New but returns a Paginator where page 1 corresponds
+// to the last chronological page and subsequent pages move backward
+// through the collection. This is useful for feeds and archives where the
+// newest content lives at the highest offset but should be the default
+// landing view, without the caller having to compute NumberOfPages itself.
+func NewReversed(numberOfItems, itemsPerPage, currentPage int) *Paginator {
+	p := New(numberOfItems, itemsPerPage, currentPage)
+	p.reversed = true
+
+	return p
+}
+
+// IsReversed returns true if the Paginator was constructed with
+// NewReversed.
+func (p *Paginator) IsReversed() bool {
+	return p.reversed
+}
+
+// translatedPage returns the underlying, non-reversed page number used for
+// offset calculations when reversed pagination is enabled.
+func (p *Paginator) translatedPage() int {
+	if !p.reversed {
+		return p.CurrentPage()
+	}
+
+	return p.NumberOfPages() - p.CurrentPage() + 1
+}
+
+// NewFromGroups returns a new Paginator for paginating pre-grouped data,
+// e.g. items grouped by date, category, or key. groups is the full list of
+// groups, not just those on the current page; groupsPerPage and
+// currentPage behave like itemsPerPage and currentPage in New, except the
+// page unit is the group itself. The NumberOfItems method therefore reports
+// the number of groups, while the NumberOfItems field of ToPagination
+// reports the total item count summed across every group.
+func NewFromGroups(groups Groups, groupsPerPage, currentPage int) *Paginator {
+	p := New(len(groups), groupsPerPage, currentPage)
+	p.groupMode = true
+	p.groups = groups
+
+	for _, g := range groups {
+		p.groupTotalItems += lenOf(g.Items)
+	}
+
+	return p
+}
+
+// PageGroups returns the groups assigned to the current page. It returns
+// nil if the Paginator was not constructed with NewFromGroups.
+func (p *Paginator) PageGroups() Groups {
+	if !p.groupMode {
+		return nil
+	}
+
+	if len(p.groups) == 0 {
+		return Groups{}
+	}
+
+	start := p.Offset()
+	if start < 0 || start >= len(p.groups) {
+		return Groups{}
+	}
+
+	end := start + p.ItemsPerPage()
+	if end > len(p.groups) {
+		end = len(p.groups)
+	}
+
+	return p.groups[start:end]
+}
+
+// lenOf returns the length of items if it is a slice, and 0 otherwise. It
+// is used to sum item counts across groups without requiring callers to
+// track totals themselves.
+func lenOf(items interface{}) int {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return 0
+	}
+
+	return v.Len()
+}
+
+// SetCursors records the cursors bracketing the current page along with
+// whether additional items remain beyond it. Callers are expected to
+// derive next and previous from the sort keys of the first and last items
+// returned by the underlying query.
+func (p *Paginator) SetCursors(next, previous *Cursor, hasMore bool) {
+	p.nextCursor = next
+	p.previousCursor = previous
+	p.hasMore = hasMore
+}
+
+// IsCursorMode returns true if the Paginator was constructed for
+// cursor-based pagination via NewFromCursor or NewFromCursorRequest.
+func (p *Paginator) IsCursorMode() bool {
+	return p.cursorMode
+}
+
+// Cursor returns the cursor the current page was resumed from, or nil if
+// this is the first page.
+func (p *Paginator) Cursor() *Cursor {
+	return p.cursor
+}
+
+// NextCursor returns the opaque cursor string for the next page, or an
+// empty string if there are no more items.
+func (p *Paginator) NextCursor() string {
+	if !p.hasMore {
+		return ""
+	}
+
+	s, _ := EncodeCursor(p.nextCursor)
+
+	return s
+}
+
+// PreviousCursor returns the opaque cursor string for the previous page,
+// or an empty string if the current page is the first one.
+func (p *Paginator) PreviousCursor() string {
+	s, _ := EncodeCursor(p.previousCursor)
+
+	return s
+}
+
+// HasMore returns true if there are more items beyond the current page.
+// It is only meaningful in cursor mode.
+func (p *Paginator) HasMore() bool {
+	return p.hasMore
+}
+
 // ToPagination returns a Pagination instance which can be serialized and
 // returned in API responses
 func (p *Paginator) ToPagination() Pagination {
-	return Pagination{
+	numberOfItems := p.NumberOfItems()
+	if p.groupMode {
+		numberOfItems = p.groupTotalItems
+	}
+
+	pagination := Pagination{
 		ItemsPerPage:  p.ItemsPerPage(),
-		NumberOfItems: p.NumberOfItems(),
-		CurrentPage:   p.CurrentPage(),
-		Offset:        p.Offset(),
-		NextPage:      p.NextPage(),
-		PreviousPage:  p.PreviousPage(),
-		TotalPages:    p.NumberOfPages(),
+		NumberOfItems: numberOfItems,
 		Data:          make([]interface{}, 0),
 	}
+
+	if p.IsCursorMode() {
+		pagination.NextCursor = p.NextCursor()
+		pagination.PreviousCursor = p.PreviousCursor()
+	} else {
+		pagination.CurrentPage = p.CurrentPage()
+		pagination.Offset = p.Offset()
+		pagination.NextPage = p.NextPage()
+		pagination.PreviousPage = p.PreviousPage()
+		pagination.TotalPages = p.NumberOfPages()
+	}
+
+	if p.groupMode {
+		pagination.PageGroups = p.PageGroups()
+	}
+
+	pagination.Reversed = p.IsReversed()
+
+	return pagination
 }
 
 // ToPaginationWithData is like ToPagination but it also includes some arbitrary
-// data, which usually ends up being the databa being paginated.
+// data, which usually ends up being the databa being paginated. In group mode,
+// ToPagination already derives PageGroups from the paginator's own state, so
+// slice is ignored; there is nothing left for Data to carry.
 func (p *Paginator) ToPaginationWithData(slice interface{}) Pagination {
 	pagination := p.ToPagination()
 
+	if p.groupMode {
+		return pagination
+	}
+
 	pagination.Data = interfaceSlice(slice)
 
 	return pagination
@@ -105,33 +443,79 @@ func interfaceSlice(slice interface{}) []interface{} {
 	return ret
 }
 
-// PagesStream returns a channel that will be incremented to
-// the available number of pages. Useful to range over when
-// building a list of pages.
-func (p *Paginator) PagesStream() chan int {
-	stream := make(chan int)
-	go func() {
-		for i := 1; i <= p.NumberOfPages(); i++ {
-			stream <- i
-		}
-		close(stream)
-	}()
-	return stream
+// Iterator walks the page numbers of a Paginator one at a time. Unlike the
+// channel PagesStream used to return, an Iterator that is abandoned before
+// reaching the end does not leak a goroutine: simply stop calling Next, or
+// call Stop to make that explicit.
+type Iterator struct {
+	current int
+	total   int
+	done    bool
+}
+
+// Next advances the iterator and returns the next page number along with
+// true, or (0, false) once the iterator is exhausted or has been stopped.
+func (it *Iterator) Next() (int, bool) {
+	if it.done || it.current >= it.total {
+		return 0, false
+	}
+
+	it.current++
+
+	return it.current, true
+}
+
+// Stop marks the iterator as exhausted, so subsequent calls to Next return
+// false without doing any further work.
+func (it *Iterator) Stop() {
+	it.done = true
+}
+
+// PagesStream returns an Iterator over the available page numbers. Useful
+// for building a list of pages without allocating a slice up front.
+func (p *Paginator) PagesStream() *Iterator {
+	return &Iterator{total: p.NumberOfPages()}
 }
 
-// Offset calculates the offset into the collection the current page represents.
+// Offset calculates the offset into the collection the current page
+// represents. When the Paginator was created with NewReversed, this is
+// computed against the reversed ordering, so CurrentPage 1 yields the
+// offset of the last chronological page.
 func (p *Paginator) Offset() int {
-	return (p.CurrentPage() - 1) * p.ItemsPerPage()
+	return (p.translatedPage() - 1) * p.ItemsPerPage()
 }
 
 // NumberOfPages calculates the number of pages needed
-// based on number of items and items per page.
+// based on number of items and items per page, capped at MaxPages if one
+// was set with SetMaxPages.
 func (p *Paginator) NumberOfPages() int {
-	return int(math.Ceil(float64(p.NumberOfItems()) / float64(p.ItemsPerPage())))
+	n := int(math.Ceil(float64(p.NumberOfItems()) / float64(p.ItemsPerPage())))
+
+	if p.maxPages > 0 && n > p.maxPages {
+		return p.maxPages
+	}
+
+	return n
+}
+
+// SetMaxPages caps the value returned by NumberOfPages at n. This is
+// useful when a backend refuses to count beyond a certain number of rows
+// and NumberOfPages would otherwise overestimate how many pages exist. A
+// value of 0 (the default) means no cap. CurrentPage is re-normalized
+// against the new cap, mirroring the clamp New already does against the
+// uncapped page count.
+func (p *Paginator) SetMaxPages(n int) {
+	p.maxPages = n
+
+	if p.currentPage > p.NumberOfPages() {
+		p.currentPage = p.NumberOfPages()
+	}
 }
 
 // PreviousPage returns the page number of the page before current page.
-// If current page is the first in the list of pages, 1 is returned.
+// If current page is the first in the list of pages, 1 is returned. This
+// is unaffected by NewReversed: CurrentPage is always a plain 1..N
+// counter, only Offset translates it against the reversed ordering.
 func (p *Paginator) PreviousPage() int {
 	if p.CurrentPage() <= 1 {
 		return 1
@@ -167,6 +551,54 @@ func (p *Paginator) Pages() []int {
 	return s
 }
 
+// PageEntry represents a single entry in a windowed page list, suitable
+// for rendering a numeric pager such as "1 … 4 5 [6] 7 8 … 42". An
+// IsEllipsis entry stands in for a gap of omitted page numbers and its
+// Number is meaningless.
+type PageEntry struct {
+	Number     int
+	IsEllipsis bool
+	IsCurrent  bool
+}
+
+// PagesWindow returns a bounded list of PageEntry values suitable for
+// rendering a numeric pager, instead of the unbounded list returned by
+// Pages. Page 1 and the last page are always included, pages within
+// radius of CurrentPage are included, and a single ellipsis entry fills
+// any gap larger than one page between consecutive included pages.
+func (p *Paginator) PagesWindow(radius int) []PageEntry {
+	total := p.NumberOfPages()
+	if total < 1 {
+		return []PageEntry{}
+	}
+
+	current := p.CurrentPage()
+
+	included := map[int]bool{1: true, total: true}
+	for i := current - radius; i <= current+radius; i++ {
+		if i >= 1 && i <= total {
+			included[i] = true
+		}
+	}
+
+	numbers := make([]int, 0, len(included))
+	for n := range included {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	entries := make([]PageEntry, 0, len(numbers))
+	for i, n := range numbers {
+		if i > 0 && n-numbers[i-1] > 1 {
+			entries = append(entries, PageEntry{IsEllipsis: true})
+		}
+
+		entries = append(entries, PageEntry{Number: n, IsCurrent: n == current})
+	}
+
+	return entries
+}
+
 // Show returns true if the pagination should be used.
 // Ie. if there is more than one page.
 func (p *Paginator) Show() bool {
@@ -187,3 +619,73 @@ func (p *Paginator) NumberOfItems() int {
 func (p *Paginator) ItemsPerPage() int {
 	return p.itemsPerPage
 }
+
+// TypedPaginator pairs a Paginator with the concrete item type it is
+// paginating, avoiding the reflect-based interfaceSlice conversion used by
+// Paginator.ToPaginationWithData.
+type TypedPaginator[T any] struct {
+	*Paginator
+
+	items []T
+}
+
+// Paginate returns a TypedPaginator over items, paginated perPage items at
+// a time. It is the generic counterpart to New, giving callers
+// compile-time type safety on the paginated payload.
+func Paginate[T any](items []T, perPage, page int) *TypedPaginator[T] {
+	return &TypedPaginator[T]{
+		Paginator: New(len(items), perPage, page),
+		items:     items,
+	}
+}
+
+// PageItems returns the slice of items belonging to the current page.
+func (tp *TypedPaginator[T]) PageItems() []T {
+	if len(tp.items) == 0 {
+		return []T{}
+	}
+
+	start := tp.Offset()
+	if start < 0 || start >= len(tp.items) {
+		return []T{}
+	}
+
+	end := start + tp.ItemsPerPage()
+	if end > len(tp.items) {
+		end = len(tp.items)
+	}
+
+	return tp.items[start:end]
+}
+
+// TypedPagination is the generic counterpart to Pagination, carrying Data
+// as []T instead of []interface{}.
+type TypedPagination[T any] struct {
+	ItemsPerPage  int  `json:"per_page"`
+	NumberOfItems int  `json:"total_entries"`
+	CurrentPage   int  `json:"page"`
+	Offset        int  `json:"offset"`
+	NextPage      int  `json:"next_page"`
+	PreviousPage  int  `json:"previous_page"`
+	TotalPages    int  `json:"total_pages"`
+	Reversed      bool `json:"reversed"`
+	Data          []T  `json:"data"`
+}
+
+// ToPaginationWithData returns a TypedPagination populated with the
+// current page's items, giving callers compile-time type safety on the
+// payload instead of the []interface{} used by
+// Paginator.ToPaginationWithData.
+func (tp *TypedPaginator[T]) ToPaginationWithData() TypedPagination[T] {
+	return TypedPagination[T]{
+		ItemsPerPage:  tp.ItemsPerPage(),
+		NumberOfItems: tp.NumberOfItems(),
+		CurrentPage:   tp.CurrentPage(),
+		Offset:        tp.Offset(),
+		NextPage:      tp.NextPage(),
+		PreviousPage:  tp.PreviousPage(),
+		TotalPages:    tp.NumberOfPages(),
+		Reversed:      tp.IsReversed(),
+		Data:          tp.PageItems(),
+	}
+}
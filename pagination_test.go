@@ -2,6 +2,7 @@ package pagination
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -196,14 +197,219 @@ func Test_PagesStream(t *testing.T) {
 	p := New(28, 25, 2)
 	result := make([]int, 0, 5)
 	expectedLength := 2
-	for i := range p.PagesStream() {
+
+	it := p.PagesStream()
+	for {
+		i, ok := it.Next()
+		if !ok {
+			break
+		}
 		result = append(result, i)
 	}
+
 	if len(result) != expectedLength {
 		t.Errorf("Paginator#Pages expected %d, got %d", expectedLength, len(result))
 	}
 }
 
+func Test_PagesStreamStop(t *testing.T) {
+	p := New(100, 1, 1)
+	it := p.PagesStream()
+
+	first, ok := it.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 1, first)
+
+	it.Stop()
+
+	_, ok = it.Next()
+	assert.False(t, ok)
+}
+
+func Test_CursorMode(t *testing.T) {
+	first, err := NewFromCursorRequest(2, mustRequest("http://10.0.0.1/"))
+	assert.NoError(t, err)
+	assert.True(t, first.IsCursorMode())
+	assert.Nil(t, first.Cursor())
+	assert.Equal(t, "", first.NextCursor())
+	assert.Equal(t, "", first.PreviousCursor())
+	assert.False(t, first.HasMore())
+
+	next := &Cursor{Key: "42", Direction: "forward"}
+	first.SetCursors(next, nil, true)
+	assert.True(t, first.HasMore())
+
+	encoded := first.NextCursor()
+	assert.NotEmpty(t, encoded)
+
+	decoded, err := DecodeCursor(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, next, decoded)
+
+	second, err := NewFromCursorRequest(2, mustRequest("http://10.0.0.1/?cursor="+encoded))
+	assert.NoError(t, err)
+	assert.Equal(t, next, second.Cursor())
+
+	pagination := first.ToPagination()
+	assert.Equal(t, encoded, pagination.NextCursor)
+	assert.Equal(t, "", pagination.PreviousCursor)
+	assert.Equal(t, 0, pagination.Offset)
+	assert.Equal(t, 0, pagination.CurrentPage)
+	assert.Equal(t, 0, pagination.TotalPages)
+}
+
+func mustRequest(url string) *http.Request {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return req
+}
+
+func Test_GroupedPagination(t *testing.T) {
+	groups := Groups{
+		{Key: "2026-07-25", Items: []interface{}{"a", "b"}},
+		{Key: "2026-07-26", Items: []interface{}{"c"}},
+		{Key: "2026-07-27", Items: []interface{}{"d", "e", "f"}},
+	}
+
+	p := NewFromGroups(groups, 2, 1)
+	assert.Equal(t, 2, len(p.PageGroups()))
+	assert.Equal(t, "2026-07-25", p.PageGroups()[0].Key)
+
+	pagination := p.ToPaginationWithData(groups[:2])
+	assert.Equal(t, 6, pagination.NumberOfItems)
+	assert.Equal(t, 2, len(pagination.PageGroups))
+
+	second := NewFromGroups(groups, 2, 2)
+	assert.Equal(t, 1, len(second.PageGroups()))
+	assert.Equal(t, "2026-07-27", second.PageGroups()[0].Key)
+
+	// Passing the full, unsliced groups collection must not leak beyond
+	// the current page's subset.
+	full := p.ToPaginationWithData(groups)
+	assert.Equal(t, 2, len(full.PageGroups))
+}
+
+func Test_GroupedPaginationEmpty(t *testing.T) {
+	p := NewFromGroups(Groups{}, 5, 1)
+
+	assert.NotPanics(t, func() {
+		pagination := p.ToPagination()
+		assert.Equal(t, 0, len(pagination.PageGroups))
+	})
+}
+
+func Test_ReversedPagination(t *testing.T) {
+	forward := New(10, 3, 1)
+	reversed := NewReversed(10, 3, 1)
+
+	assert.True(t, reversed.IsReversed())
+	assert.False(t, forward.IsReversed())
+
+	assert.Equal(t, forward.NumberOfPages(), reversed.NumberOfPages())
+	assert.Equal(t, 9, reversed.Offset())
+	assert.Equal(t, 0, forward.Offset())
+
+	last := NewReversed(10, 3, 4)
+	assert.Equal(t, 0, last.Offset())
+
+	pagination := reversed.ToPagination()
+	assert.True(t, pagination.Reversed)
+}
+
+func Test_PagesWindow(t *testing.T) {
+	p := New(420, 10, 6)
+	window := p.PagesWindow(2)
+
+	numbers := make([]int, 0, len(window))
+	for _, e := range window {
+		if !e.IsEllipsis {
+			numbers = append(numbers, e.Number)
+		}
+	}
+
+	assert.Equal(t, []int{1, 4, 5, 6, 7, 8, 42}, numbers)
+	assert.True(t, window[0].Number == 1)
+	assert.True(t, window[1].IsEllipsis)
+	assert.True(t, window[len(window)-1].IsEllipsis == false && window[len(window)-1].Number == 42)
+
+	for _, e := range window {
+		if e.Number == 6 {
+			assert.True(t, e.IsCurrent)
+		}
+	}
+}
+
+func Test_PagesWindowNoPages(t *testing.T) {
+	p := New(0, 10, 1)
+	assert.Equal(t, []PageEntry{}, p.PagesWindow(2))
+}
+
+func Test_MaxPages(t *testing.T) {
+	p := New(100000, 10, 1)
+	p.SetMaxPages(50)
+	assert.Equal(t, 50, p.NumberOfPages())
+}
+
+func Test_MaxPagesReclampsCurrentPage(t *testing.T) {
+	p := New(100000, 10, 9000)
+	p.SetMaxPages(50)
+
+	assert.Equal(t, 50, p.NumberOfPages())
+	assert.Equal(t, 50, p.CurrentPage())
+	assert.Equal(t, 490, p.Offset())
+}
+
+func Test_WriteHeaders(t *testing.T) {
+	req := mustRequest("http://10.0.0.1/things?page=2&filter=active")
+	p := NewFromRequestWithURL(28, 10, req)
+
+	w := httptest.NewRecorder()
+	p.WriteHeaders(w)
+
+	link := w.Header().Get("Link")
+	assert.Contains(t, link, `rel="first"`)
+	assert.Contains(t, link, `rel="last"`)
+	assert.Contains(t, link, `rel="next"`)
+	assert.Contains(t, link, `rel="prev"`)
+	assert.Contains(t, link, "filter=active")
+
+	assert.Equal(t, "items 10-20/28", w.Header().Get("Content-Range"))
+}
+
+func Test_WriteHeadersWithoutURLFactory(t *testing.T) {
+	p := New(28, 10, 2)
+	w := httptest.NewRecorder()
+	p.WriteHeaders(w)
+
+	assert.Equal(t, "", w.Header().Get("Link"))
+}
+
+func Test_Paginate(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+
+	tp := Paginate(items, 2, 2)
+	assert.Equal(t, []string{"c", "d"}, tp.PageItems())
+
+	pagination := tp.ToPaginationWithData()
+	assert.Equal(t, []string{"c", "d"}, pagination.Data)
+	assert.Equal(t, 5, pagination.NumberOfItems)
+	assert.Equal(t, 3, pagination.TotalPages)
+
+	last := Paginate(items, 2, 10)
+	assert.Equal(t, []string{"e"}, last.PageItems())
+}
+
+func Test_PaginateEmpty(t *testing.T) {
+	tp := Paginate([]string{}, 5, 1)
+
+	assert.NotPanics(t, func() {
+		assert.Equal(t, []string{}, tp.PageItems())
+	})
+}
+
 func Test_Show(t *testing.T) {
 	p := New(28, 25, 2)
 	expected := true